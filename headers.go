@@ -0,0 +1,57 @@
+package http_limiter
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HeaderStyle selects which rate limit response headers NewWithConfig
+// writes.
+type HeaderStyle uint
+
+const (
+	// Legacy writes the historical X-RateLimit-* headers.
+	Legacy HeaderStyle = iota
+
+	// Standard writes the draft-ietf-httpapi-ratelimit-headers names:
+	// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset.
+	Standard
+
+	// Both writes the Legacy and Standard headers together.
+	Both
+)
+
+// writeRateLimitHeaders sets the configured rate limit headers for an
+// allowed request. tier, when non-empty, is also exposed regardless of
+// style so clients can see which bucket they hit.
+func writeRateLimitHeaders(w http.ResponseWriter, style HeaderStyle, limit Limit, result *Result, tier string) {
+	h := w.Header()
+
+	if style != Standard {
+		h.Set("X-RateLimit-Limit", strconv.Itoa(limit.Max))
+		h.Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+	}
+
+	if style != Legacy {
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Max))
+		h.Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		h.Set("RateLimit-Reset", deltaSeconds(result.ResetAfter))
+	}
+
+	if tier != "" {
+		h.Set("X-RateLimit-Tier", tier)
+	}
+}
+
+// deltaSeconds formats d as whole seconds, rounding up so callers never
+// retry early, per RFC 7231's delta-seconds form for Retry-After.
+func deltaSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 0 {
+		seconds = 0
+	}
+
+	return strconv.FormatInt(seconds, 10)
+}
@@ -0,0 +1,82 @@
+package http_limiter
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeltaSeconds(t *testing.T) {
+	cases := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0"},
+		{time.Millisecond, "1"},
+		{500 * time.Millisecond, "1"},
+		{5 * time.Second, "5"},
+		{5*time.Second + time.Millisecond, "6"},
+	}
+
+	for _, c := range cases {
+		if got := deltaSeconds(c.in); got != c.want {
+			t.Errorf("deltaSeconds(%s) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestWriteRateLimitHeadersLegacy(t *testing.T) {
+	w := httptest.NewRecorder()
+	limit := Limit{Max: 10}
+	result := &Result{Remaining: 3, ResetAfter: 5 * time.Second}
+
+	writeRateLimitHeaders(w, Legacy, limit, result, "")
+
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "10" {
+		t.Errorf("X-RateLimit-Limit = %q, want 10", got)
+	}
+
+	if got := w.Header().Get("RateLimit-Limit"); got != "" {
+		t.Errorf("RateLimit-Limit = %q, want empty for Legacy style", got)
+	}
+}
+
+func TestWriteRateLimitHeadersStandard(t *testing.T) {
+	w := httptest.NewRecorder()
+	limit := Limit{Max: 10}
+	result := &Result{Remaining: 3, ResetAfter: 5 * time.Second}
+
+	writeRateLimitHeaders(w, Standard, limit, result, "gold")
+
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "" {
+		t.Errorf("X-RateLimit-Limit = %q, want empty for Standard style", got)
+	}
+
+	if got := w.Header().Get("RateLimit-Limit"); got != "10" {
+		t.Errorf("RateLimit-Limit = %q, want 10", got)
+	}
+
+	if got := w.Header().Get("RateLimit-Reset"); got != "5" {
+		t.Errorf("RateLimit-Reset = %q, want 5", got)
+	}
+
+	if got := w.Header().Get("X-RateLimit-Tier"); got != "gold" {
+		t.Errorf("X-RateLimit-Tier = %q, want gold", got)
+	}
+}
+
+func TestWriteRateLimitHeadersBoth(t *testing.T) {
+	w := httptest.NewRecorder()
+	limit := Limit{Max: 10}
+	result := &Result{Remaining: 3, ResetAfter: 5 * time.Second}
+
+	writeRateLimitHeaders(w, Both, limit, result, "")
+
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "10" {
+		t.Errorf("X-RateLimit-Limit = %q, want 10", got)
+	}
+
+	if got := w.Header().Get("RateLimit-Limit"); got != "10" {
+		t.Errorf("RateLimit-Limit = %q, want 10", got)
+	}
+}
@@ -0,0 +1,148 @@
+package http_limiter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const clientIPContextKey contextKey = iota
+
+// WithClientIP returns a copy of ctx carrying ip, so downstream handlers
+// can read back the IP the middleware resolved for this request.
+func WithClientIP(ctx context.Context, ip net.IP) context.Context {
+	return context.WithValue(ctx, clientIPContextKey, ip)
+}
+
+// ClientIPFromContext returns the client IP the middleware resolved for
+// this request, if any.
+func ClientIPFromContext(ctx context.Context) (net.IP, bool) {
+	ip, ok := ctx.Value(clientIPContextKey).(net.IP)
+
+	return ip, ok
+}
+
+// GetIP returns the IP address from r.RemoteAddr. It does not honor any
+// forwarded headers, since without a list of trusted proxies they are
+// attacker-controlled; use ClientIP with Config.TrustedProxies when the
+// server sits behind a reverse proxy.
+func GetIP(r *http.Request) net.IP {
+	return ClientIP(r, nil)
+}
+
+// ClientIP resolves the request's client IP. X-Forwarded-For, X-Real-IP,
+// and the RFC 7239 Forwarded header are only honored when r.RemoteAddr
+// is itself inside one of trustedProxies; otherwise they are ignored in
+// favor of r.RemoteAddr. When walking a forwarded chain, trailing
+// entries that are themselves trusted proxies are stripped and the
+// rightmost remaining (untrusted) address is used.
+func ClientIP(r *http.Request, trustedProxies []net.IPNet) net.IP {
+	remoteIP := hostIP(r.RemoteAddr)
+
+	if remoteIP == nil || !isTrusted(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		if ip := rightmostUntrusted(parseForwarded(fwd), trustedProxies); ip != nil {
+			return ip
+		}
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		if ip := rightmostUntrusted(parseXFF(xff), trustedProxies); ip != nil {
+			return ip
+		}
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		if ip := net.ParseIP(xri); ip != nil {
+			return ip
+		}
+	}
+
+	return remoteIP
+}
+
+func hostIP(remoteAddr string) net.IP {
+	remoteAddr = strings.TrimSpace(remoteAddr)
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return net.ParseIP(remoteAddr)
+	}
+
+	return net.ParseIP(host)
+}
+
+func isTrusted(ip net.IP, proxies []net.IPNet) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseXFF(header string) []net.IP {
+	parts := strings.Split(header, ",")
+	ips := make([]net.IP, 0, len(parts))
+
+	for _, part := range parts {
+		if ip := net.ParseIP(strings.TrimSpace(part)); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
+
+// parseForwarded extracts the "for=" addresses from an RFC 7239
+// Forwarded header, in the order they appear (client first, proxies
+// appended after, same order as X-Forwarded-For).
+func parseForwarded(header string) []net.IP {
+	var ips []net.IP
+
+	for _, part := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(part, ";") {
+			pair = strings.TrimSpace(pair)
+			if !strings.HasPrefix(strings.ToLower(pair), "for=") {
+				continue
+			}
+
+			value := strings.Trim(strings.TrimSpace(pair[len("for="):]), `"`)
+
+			// SplitHostPort understands "[ipv6]:port" natively; only
+			// fall back to manual bracket trimming for a bracketed
+			// address with no port, which SplitHostPort rejects.
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			} else {
+				value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			}
+
+			if ip := net.ParseIP(value); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+
+	return ips
+}
+
+// rightmostUntrusted walks ips (client first, proxies appended after)
+// from the right, skipping trusted proxies, and returns the first
+// untrusted address found.
+func rightmostUntrusted(ips []net.IP, trustedProxies []net.IPNet) net.IP {
+	for i := len(ips) - 1; i >= 0; i-- {
+		if !isTrusted(ips[i], trustedProxies) {
+			return ips[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package http_limiter
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func mustParseCIDR(t *testing.T, s string) net.IPNet {
+	t.Helper()
+
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", s, err)
+	}
+
+	return *n
+}
+
+func TestClientIPIgnoresHeadersFromUntrustedRemote(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "203.0.113.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+	ip := ClientIP(r, nil)
+	if ip.String() != "203.0.113.1" {
+		t.Errorf("ClientIP = %s, want 203.0.113.1 (headers from an untrusted remote must be ignored)", ip)
+	}
+}
+
+func TestClientIPHonorsXFFFromTrustedProxy(t *testing.T) {
+	trusted := []net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 10.0.0.2")
+
+	ip := ClientIP(r, trusted)
+	if ip.String() != "198.51.100.1" {
+		t.Errorf("ClientIP = %s, want 198.51.100.1 (rightmost untrusted entry after stripping trusted hops)", ip)
+	}
+}
+
+func TestClientIPHonorsForwardedHeader(t *testing.T) {
+	trusted := []net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Forwarded", `for="198.51.100.1:4711", for=10.0.0.2`)
+
+	ip := ClientIP(r, trusted)
+	if ip.String() != "198.51.100.1" {
+		t.Errorf("ClientIP = %s, want 198.51.100.1", ip)
+	}
+}
+
+// TestClientIPHonorsForwardedHeaderBracketedIPv6WithPort guards the
+// canonical RFC 7239 example form, a bracketed IPv6 address with a port,
+// which requires SplitHostPort (not manual bracket trimming) to parse.
+func TestClientIPHonorsForwardedHeaderBracketedIPv6WithPort(t *testing.T) {
+	trusted := []net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Forwarded", `for="[2001:db8:cafe::17]:4711", for=10.0.0.2`)
+
+	ip := ClientIP(r, trusted)
+	if ip.String() != "2001:db8:cafe::17" {
+		t.Errorf("ClientIP = %s, want 2001:db8:cafe::17", ip)
+	}
+}
+
+func TestClientIPFallsBackWhenAllHopsTrusted(t *testing.T) {
+	trusted := []net.IPNet{mustParseCIDR(t, "10.0.0.0/8")}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "10.0.0.3, 10.0.0.2")
+
+	ip := ClientIP(r, trusted)
+	if ip.String() != "10.0.0.1" {
+		t.Errorf("ClientIP = %s, want 10.0.0.1 (RemoteAddr) when every hop is trusted", ip)
+	}
+}
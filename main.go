@@ -1,11 +1,8 @@
 package http_limiter
 
 import (
-	"errors"
 	"net"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v7"
@@ -38,7 +35,13 @@ type (
 	Config struct {
 		Skipper Skipper
 
-		// Rediser
+		// Store is the backend used to track request counts. When nil,
+		// it is built from Rediser if set, or a MemoryStore otherwise.
+		// Default: RedisStore(Rediser) if Rediser is set, else MemoryStore
+		Store Store
+
+		// Rediser is used to build the default RedisStore when Store is
+		// not set directly.
 		Rediser *redis.Client
 
 		// Max number of recent connections
@@ -92,6 +95,64 @@ type (
 		//	 w.WriteHeader(http.StatusInternalServerError)
 		// }
 		ErrHandler func(error, http.ResponseWriter, *http.Request)
+
+		// Rules let a single middleware instance enforce a different
+		// Limit (and optional allowlist bypass) per route and/or HTTP
+		// method. The first matching rule wins, and its Limit replaces
+		// any Tiers or Groups Limit; requests that match no rule keep
+		// the Tiers or Groups Limit, or else the top-level
+		// Max/Burst/Period/Algorithm.
+		// Default: none
+		Rules []Rule
+
+		// Identify resolves the caller's API key/account and tier from
+		// the request, e.g. by parsing an Authorization header. When
+		// exempt is true the request bypasses the limiter entirely. When
+		// id is non-empty it is used as the rate limit key instead of
+		// Key, so each caller gets its own bucket. When tier matches an
+		// entry in Tiers, that Limit overrides Max/Burst/Period/Algorithm.
+		// Default: nil (disabled, falls back to Key/Rules/top-level Limit)
+		Identify func(r *http.Request) (id string, tier string, exempt bool)
+
+		// Tiers maps a tier name, as returned by Identify, to the Limit
+		// that replaces Max/Burst/Period/Algorithm for callers in that
+		// tier.
+		// Default: none
+		Tiers map[string]Limit
+
+		// HeaderStyle selects which rate limit response headers are
+		// written: the legacy X-RateLimit-* names, the draft-ietf
+		// RateLimit-* names, or Both.
+		// Default: Legacy
+		HeaderStyle HeaderStyle
+
+		// Groups maps a group name, as returned by Group, to the Limit
+		// that replaces Max/Burst/Period/Algorithm for requests in that
+		// group, e.g. distinct buckets for "read", "write", "delete".
+		// Default: none
+		Groups map[string]Limit
+
+		// Group selects which Groups entry a request belongs to. Its
+		// name is namespaced into the rate limit key's prefix so each
+		// group gets an independent bucket.
+		// Default: nil (disabled)
+		Group func(r *http.Request) string
+
+		// Cost returns how many tokens r should consume, letting
+		// expensive endpoints (large uploads, batch queries) charge
+		// more than cheap ones.
+		// Default: func(r *http.Request) int64 { return 1 }
+		Cost func(r *http.Request) int64
+
+		// TrustedProxies lists the CIDRs that are allowed to set
+		// X-Forwarded-For, X-Real-IP, and Forwarded headers. Requests
+		// arriving directly from outside these ranges have those
+		// headers ignored, since they would otherwise let any caller
+		// spoof its IP. When Key is nil, the Key that NewWithConfig
+		// installs resolves the client IP via ClientIP with these
+		// proxies; DefaultConfig.Key uses GetIP and does not read them.
+		// Default: none (forwarded headers are never trusted)
+		TrustedProxies []net.IPNet
 	}
 	Skipper func(*http.Request) bool
 )
@@ -103,10 +164,6 @@ func New(rediser *redis.Client) func(http.Handler) http.Handler {
 }
 
 func NewWithConfig(config Config) func(http.Handler) http.Handler {
-	if config.Rediser == nil {
-		panic(errors.New("redis client is missing"))
-	}
-
 	if config.Skipper == nil {
 		config.Skipper = DefaultConfig.Skipper
 	}
@@ -140,7 +197,10 @@ func NewWithConfig(config Config) func(http.Handler) http.Handler {
 	}
 
 	if config.Key == nil {
-		config.Key = DefaultConfig.Key
+		trustedProxies := config.TrustedProxies
+		config.Key = func(r *http.Request) string {
+			return ClientIP(r, trustedProxies).String()
+		}
 	}
 
 	if config.Handler == nil {
@@ -157,13 +217,21 @@ func NewWithConfig(config Config) func(http.Handler) http.Handler {
 		}
 	}
 
-	limiter := go_limiter.NewLimiter(config.Rediser)
-	limit := &go_limiter.Limit{
+	if config.Store == nil {
+		if config.Rediser != nil {
+			config.Store = NewRedisStore(config.Rediser)
+		} else {
+			config.Store = NewMemoryStore()
+		}
+	}
+
+	defaultLimit := Limit{
+		Max:       config.Max,
+		Burst:     config.Burst,
 		Period:    config.Period,
 		Algorithm: config.Algorithm,
-		Rate:      int64(config.Max),
-		Burst:     int64(config.Burst),
 	}
+	rules := compileRules(config.Rules, defaultLimit)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -173,7 +241,66 @@ func NewWithConfig(config Config) func(http.Handler) http.Handler {
 				return
 			}
 
-			result, err := limiter.Allow(config.Key(r), limit)
+			r = r.WithContext(WithClientIP(r.Context(), ClientIP(r, config.TrustedProxies)))
+
+			limit := defaultLimit
+			key := config.Key(r)
+			tier := ""
+
+			if config.Identify != nil {
+				id, t, exempt := config.Identify(r)
+				if exempt {
+					next.ServeHTTP(w, r)
+
+					return
+				}
+
+				if id != "" {
+					key = id
+				}
+
+				tier = t
+
+				if tierLimit, ok := config.Tiers[tier]; ok {
+					limit = resolveLimit(tierLimit, defaultLimit)
+				}
+			}
+
+			group := ""
+
+			if config.Group != nil {
+				group = config.Group(r)
+
+				if groupLimit, ok := config.Groups[group]; ok {
+					limit = resolveLimit(groupLimit, defaultLimit)
+				}
+			}
+
+			ruleName := ""
+
+			for _, rule := range rules {
+				if !rule.matchRule(r) {
+					continue
+				}
+
+				if rule.bypasses(r) {
+					next.ServeHTTP(w, r)
+
+					return
+				}
+
+				limit = rule.limit
+				ruleName = rule.name
+
+				break
+			}
+
+			cost := int64(1)
+			if config.Cost != nil {
+				cost = config.Cost(r)
+			}
+
+			result, err := config.Store.Allow(rateLimitKey(config.Prefix, group, ruleName, key), limit, cost)
 			if err != nil {
 				if config.SkipOnError {
 					next.ServeHTTP(w, r)
@@ -188,9 +315,9 @@ func NewWithConfig(config Config) func(http.Handler) http.Handler {
 
 			// Check if hits exceed the max
 			if !result.Allowed {
-				// Return response with Retry-After header
-				// https://tools.ietf.org/html/rfc6584
-				w.Header().Set("Retry-After", strconv.FormatInt(time.Now().Add(result.RetryAfter).Unix(), 10))
+				// Return response with Retry-After header, as
+				// delta-seconds per RFC 7231.
+				w.Header().Set("Retry-After", deltaSeconds(result.RetryAfter))
 
 				// Call Handler func
 				config.Handler(w, r)
@@ -199,40 +326,31 @@ func NewWithConfig(config Config) func(http.Handler) http.Handler {
 			}
 
 			// We can continue, update RateLimit headers
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(config.Max))
-			w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.ResetAfter).Unix(), 10))
+			writeRateLimitHeaders(w, config.HeaderStyle, limit, result, tier)
 
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
-// DefaultSkipper returns false which processes the middleware.
-func DefaultSkipper(r *http.Request) bool {
-	return false
-}
+// rateLimitKey namespaces key under prefix, and under group and/or rule
+// as well when set, so groups and rules with different Limits each get
+// an independent bucket in the Store.
+func rateLimitKey(prefix, group, rule, key string) string {
+	namespaced := prefix
 
-// GetIP returns IP address from request.
-// It will lookup IP in X-Forwarded-For and X-Real-IP headers.
-func GetIP(r *http.Request) net.IP {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip != "" {
-		parts := strings.SplitN(ip, ",", 2)
-		part := strings.TrimSpace(parts[0])
-		return net.ParseIP(part)
+	if group != "" {
+		namespaced += ":" + group
 	}
 
-	ip = strings.TrimSpace(r.Header.Get("X-Real-IP"))
-	if ip != "" {
-		return net.ParseIP(ip)
+	if rule != "" {
+		namespaced += ":" + rule
 	}
 
-	remoteAddr := strings.TrimSpace(r.RemoteAddr)
-	host, _, err := net.SplitHostPort(remoteAddr)
-	if err != nil {
-		return net.ParseIP(remoteAddr)
-	}
+	return namespaced + ":" + key
+}
 
-	return net.ParseIP(host)
+// DefaultSkipper returns false which processes the middleware.
+func DefaultSkipper(r *http.Request) bool {
+	return false
 }
@@ -0,0 +1,185 @@
+package http_limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRequest(remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+
+	return r
+}
+
+func TestIdentifyExemptBypassesLimiter(t *testing.T) {
+	handler := NewWithConfig(Config{
+		Max:    1,
+		Burst:  1,
+		Period: time.Minute,
+		Identify: func(r *http.Request) (string, string, bool) {
+			return "", "", true
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newTestRequest("198.51.100.1:1234"))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d, want 200 (exempt caller must never be limited)", i, w.Code)
+		}
+	}
+}
+
+func TestIdentifyTierOverridesLimit(t *testing.T) {
+	handler := NewWithConfig(Config{
+		Max:    1,
+		Burst:  1,
+		Period: time.Minute,
+		Identify: func(r *http.Request) (string, string, bool) {
+			return "", "gold", false
+		},
+		Tiers: map[string]Limit{
+			"gold": {Max: 5, Burst: 5, Period: time.Minute, Algorithm: SlidingWindowAlgorithm},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// The top-level Max of 1 would deny the second request; the "gold"
+	// tier's Max of 5 must be used instead.
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newTestRequest("198.51.100.2:1234"))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got status %d, want 200 (gold tier should allow up to 5)", i, w.Code)
+		}
+
+		if got := w.Header().Get("X-RateLimit-Tier"); got != "gold" {
+			t.Errorf("request %d: X-RateLimit-Tier = %q, want gold", i, got)
+		}
+	}
+}
+
+func TestIdentifyIDNamespacesKeyPerCaller(t *testing.T) {
+	callerID := "anonymous"
+
+	handler := NewWithConfig(Config{
+		Max:    1,
+		Burst:  1,
+		Period: time.Minute,
+		Identify: func(r *http.Request) (string, string, bool) {
+			return callerID, "", false
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Both callers share a RemoteAddr, so without Identify overriding the
+	// key they would collide on a single bucket.
+	callerID = "alice"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newTestRequest("198.51.100.3:1234"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("alice's first request: got status %d, want 200", w.Code)
+	}
+
+	callerID = "bob"
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newTestRequest("198.51.100.3:1234"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("bob's first request: got status %d, want 200 (distinct caller id must get its own bucket)", w.Code)
+	}
+}
+
+func groupByMethod(r *http.Request) string {
+	if r.Method == http.MethodPost {
+		return "write"
+	}
+
+	return "read"
+}
+
+func TestGroupSelectsConfiguredLimit(t *testing.T) {
+	handler := NewWithConfig(Config{
+		Max:    1,
+		Burst:  1,
+		Period: time.Minute,
+		Group:  groupByMethod,
+		Groups: map[string]Limit{
+			"write": {Max: 1, Burst: 1, Period: time.Minute, Algorithm: SlidingWindowAlgorithm},
+			"read":  {Max: 1000, Burst: 1000, Period: time.Minute, Algorithm: SlidingWindowAlgorithm},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	post := func() *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.RemoteAddr = "198.51.100.4:1234"
+
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, post())
+	if w.Code != http.StatusOK {
+		t.Fatalf("first write: got status %d, want 200", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, post())
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second write: got status %d, want 429 (write group's Max is 1)", w.Code)
+	}
+}
+
+// TestGroupUsesIndependentBuckets guards against the read and write
+// groups sharing one Store bucket for the same client, the same bug
+// class namespacing fixed for Rules.
+func TestGroupUsesIndependentBuckets(t *testing.T) {
+	handler := NewWithConfig(Config{
+		Max:    1000,
+		Burst:  1000,
+		Period: time.Minute,
+		Group:  groupByMethod,
+		Groups: map[string]Limit{
+			"write": {Max: 1, Burst: 1, Period: time.Minute, Algorithm: SlidingWindowAlgorithm},
+			"read":  {Max: 1000, Burst: 1000, Period: time.Minute, Algorithm: SlidingWindowAlgorithm},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	remoteAddr := "198.51.100.5:1234"
+
+	post := httptest.NewRequest(http.MethodPost, "/", nil)
+	post.RemoteAddr = remoteAddr
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, post)
+	if w.Code != http.StatusOK {
+		t.Fatalf("write request: got status %d, want 200", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, post)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second write request: got status %d, want 429", w.Code)
+	}
+
+	get := httptest.NewRequest(http.MethodGet, "/", nil)
+	get.RemoteAddr = remoteAddr
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, get)
+	if w.Code != http.StatusOK {
+		t.Fatalf("read request after exhausting write bucket: got status %d, want 200 (groups must be independent)", w.Code)
+	}
+}
@@ -0,0 +1,183 @@
+package http_limiter
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Limit describes the Max/Burst/Period/Algorithm tuple that is
+	// normally set at the top level of Config. It is reused wherever a
+	// request needs its own bucket, e.g. per-route Rules.
+	Limit struct {
+		// Max number of recent connections
+		Max int
+
+		// Burst
+		Burst int
+
+		// Period
+		Period time.Duration
+
+		// Algorithm
+		Algorithm uint
+	}
+
+	// Rule lets a single middleware instance enforce a different Limit
+	// for a subset of requests, selected by path pattern, method, or
+	// both. Rules are evaluated in order and the first match wins.
+	Rule struct {
+		// Pattern is matched against r.URL.Path. It accepts a glob
+		// pattern understood by path.Match (e.g. "/api/write/*"), or,
+		// when it starts with "^" (e.g. "^/api/\d+$"), a regular
+		// expression.
+		Pattern string
+
+		// Methods restricts the rule to the given HTTP methods.
+		// Default: all methods match.
+		Methods []string
+
+		// AllowUserAgents bypasses limiting entirely for requests whose
+		// User-Agent header contains one of these substrings, e.g. a
+		// known health-check probe.
+		AllowUserAgents []string
+
+		// AllowOrigins bypasses limiting entirely for requests whose
+		// Origin header matches one of these values exactly.
+		AllowOrigins []string
+
+		// Limit overrides Max/Burst/Period/Algorithm for requests
+		// matching this rule. Zero fields fall back to the top-level
+		// Config values.
+		Limit Limit
+	}
+
+	compiledRule struct {
+		// name namespaces this rule's rate limit key so two rules with
+		// different Limits never share Store state.
+		name      string
+		matchPath func(string) bool
+		methods   map[string]struct{}
+		allowUAs  []string
+		allowOris []string
+		limit     Limit
+	}
+)
+
+// compileRules precompiles Rules against the already-defaulted Config so
+// path matchers, method sets and Limits are built once rather than on
+// every request.
+func compileRules(rules []Rule, fallback Limit) []compiledRule {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for i, rule := range rules {
+		cr := compiledRule{
+			name:      "rule" + strconv.Itoa(i),
+			matchPath: newPathMatcher(rule.Pattern),
+			allowUAs:  rule.AllowUserAgents,
+			allowOris: rule.AllowOrigins,
+			limit:     resolveLimit(rule.Limit, fallback),
+		}
+
+		if len(rule.Methods) > 0 {
+			cr.methods = make(map[string]struct{}, len(rule.Methods))
+			for _, m := range rule.Methods {
+				cr.methods[strings.ToUpper(m)] = struct{}{}
+			}
+		}
+
+		compiled = append(compiled, cr)
+	}
+
+	return compiled
+}
+
+// newPathMatcher returns a matcher for pattern. Patterns starting with
+// "^" are compiled as regular expressions; everything else is
+// matched with path.Match. An invalid pattern never matches.
+func newPathMatcher(pattern string) func(string) bool {
+	if strings.HasPrefix(pattern, "^") {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return func(string) bool { return false }
+		}
+
+		return re.MatchString
+	}
+
+	return func(p string) bool {
+		ok, err := path.Match(pattern, p)
+
+		return err == nil && ok
+	}
+}
+
+// resolveLimit fills zero fields of l with the corresponding field from
+// fallback, the same pattern NewWithConfig uses for Config defaults.
+func resolveLimit(l, fallback Limit) Limit {
+	if l.Max == 0 {
+		l.Max = fallback.Max
+	}
+
+	if l.Burst == 0 {
+		l.Burst = fallback.Burst
+	}
+
+	if l.Period == 0 {
+		l.Period = fallback.Period
+	}
+
+	if l.Algorithm == 0 {
+		l.Algorithm = fallback.Algorithm
+	}
+
+	return l
+}
+
+// matchRule reports whether r matches the rule's path pattern and method
+// restriction.
+func (cr compiledRule) matchRule(r *http.Request) bool {
+	if !cr.matchPath(r.URL.Path) {
+		return false
+	}
+
+	if cr.methods != nil {
+		if _, ok := cr.methods[r.Method]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bypasses reports whether r is exempt from limiting entirely because it
+// carries an allowlisted User-Agent or Origin.
+func (cr compiledRule) bypasses(r *http.Request) bool {
+	if len(cr.allowUAs) > 0 {
+		ua := r.UserAgent()
+		for _, allowed := range cr.allowUAs {
+			if strings.Contains(ua, allowed) {
+				return true
+			}
+		}
+	}
+
+	if len(cr.allowOris) > 0 {
+		origin := r.Header.Get("Origin")
+		for _, allowed := range cr.allowOris {
+			if origin == allowed {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package http_limiter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewPathMatcherGlobAndRegex(t *testing.T) {
+	glob := newPathMatcher("/api/write/*")
+	if !glob("/api/write/things") {
+		t.Error("glob pattern should match /api/write/things")
+	}
+
+	if glob("/api/read/things") {
+		t.Error("glob pattern should not match /api/read/things")
+	}
+
+	re := newPathMatcher("^/api/(read|write)/\\d+$")
+	if !re("/api/read/42") {
+		t.Error("regex pattern should match /api/read/42")
+	}
+
+	if re("/api/read/abc") {
+		t.Error("regex pattern should not match /api/read/abc")
+	}
+}
+
+func TestCompiledRuleMatchMethodAndBypass(t *testing.T) {
+	rules := compileRules([]Rule{
+		{
+			Pattern:         "/api/*",
+			Methods:         []string{"post"},
+			AllowUserAgents: []string{"healthcheck"},
+		},
+	}, Limit{Max: 10, Burst: 10, Period: time.Minute, Algorithm: SlidingWindowAlgorithm})
+
+	rule := rules[0]
+
+	get := httptest.NewRequest(http.MethodGet, "/api/widgets", nil)
+	if rule.matchRule(get) {
+		t.Error("rule restricted to POST should not match a GET request")
+	}
+
+	post := httptest.NewRequest(http.MethodPost, "/api/widgets", nil)
+	if !rule.matchRule(post) {
+		t.Error("rule should match a POST request under /api/*")
+	}
+
+	post.Header.Set("User-Agent", "healthcheck/1.0")
+	if !rule.bypasses(post) {
+		t.Error("request with an allowlisted User-Agent should bypass the rule")
+	}
+}
+
+// TestRulesUseIndependentBuckets guards against two differently
+// configured Rules sharing one Store bucket: a client exhausting the
+// strict /api/write/* limit must not be denied on /api/read/*, which
+// has a much larger budget.
+func TestRulesUseIndependentBuckets(t *testing.T) {
+	handler := NewWithConfig(Config{
+		Max:    100,
+		Burst:  100,
+		Period: time.Minute,
+		Rules: []Rule{
+			{Pattern: "/api/write/*", Limit: Limit{Max: 1, Burst: 1, Period: time.Minute, Algorithm: SlidingWindowAlgorithm}},
+			{Pattern: "/api/read/*", Limit: Limit{Max: 100, Burst: 100, Period: time.Minute, Algorithm: SlidingWindowAlgorithm}},
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	newRequest := func(path string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = "203.0.113.9:1234"
+
+		return r
+	}
+
+	// Exhaust the single-token write bucket.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest("/api/write/widgets"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first write request: got status %d, want 200", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest("/api/write/widgets"))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second write request: got status %d, want 429", w.Code)
+	}
+
+	// The read bucket, on the same client, must still have its full
+	// budget rather than sharing state with the write rule.
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest("/api/read/widgets"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("read request after exhausting write bucket: got status %d, want 200 (buckets must be independent)", w.Code)
+	}
+}
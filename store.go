@@ -0,0 +1,115 @@
+package http_limiter
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/shareed2k/go_limiter"
+)
+
+// Result is the outcome of a single Allow check against a Store.
+type Result struct {
+	// Allowed reports whether the request should be let through.
+	Allowed bool
+
+	// Remaining is the number of requests left in the current window.
+	Remaining int64
+
+	// RetryAfter is how long the caller should wait before retrying.
+	// It is zero when Allowed is true.
+	RetryAfter time.Duration
+
+	// ResetAfter is how long until the window resets.
+	ResetAfter time.Duration
+}
+
+// Store abstracts the backend that tracks request counts for a key, so
+// NewWithConfig is not tied to a single backing service. The built-in
+// implementations are RedisStore, which wraps go_limiter for multi-node
+// deployments, and MemoryStore, an in-process store for single-node
+// deployments, unit tests, and CI. cost lets a request consume more than
+// one token, e.g. for expensive endpoints; a cost of 1 is the common case.
+type Store interface {
+	Allow(key string, limit Limit, cost int64) (*Result, error)
+}
+
+// RedisStore is a Store backed by Redis via go_limiter. It is the
+// default Store when Config.Rediser is set.
+type RedisStore struct {
+	limiter *go_limiter.Limiter
+}
+
+// NewRedisStore builds a RedisStore from an existing redis client.
+func NewRedisStore(rediser *redis.Client) *RedisStore {
+	return &RedisStore{limiter: go_limiter.NewLimiter(rediser)}
+}
+
+func toGoLimit(l Limit) *go_limiter.Limit {
+	return &go_limiter.Limit{
+		Period:    l.Period,
+		Algorithm: l.Algorithm,
+		Rate:      int64(l.Max),
+		Burst:     int64(l.Burst),
+	}
+}
+
+// Allow deducts cost tokens from key's bucket. go_limiter only exposes a
+// single-token Allow, so the first call both checks and consumes one
+// token; its Remaining tells us the capacity that existed right before
+// that consumption, which lets us decide whether the rest of cost fits
+// before consuming any more. If it doesn't fit, we stop there instead of
+// calling Allow further and burning tokens on a request that is denied
+// overall.
+func (s *RedisStore) Allow(key string, limit Limit, cost int64) (*Result, error) {
+	if cost <= 0 {
+		cost = 1
+	}
+
+	glimit := toGoLimit(limit)
+
+	first, err := s.limiter.Allow(key, glimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !first.Allowed || cost == 1 {
+		return toResult(first), nil
+	}
+
+	if !costFits(first, cost) {
+		return &Result{
+			Remaining:  first.Remaining,
+			RetryAfter: first.ResetAfter,
+			ResetAfter: first.ResetAfter,
+		}, nil
+	}
+
+	result := first
+
+	for i := int64(1); i < cost; i++ {
+		r, err := s.limiter.Allow(key, glimit)
+		if err != nil {
+			return nil, err
+		}
+
+		result = r
+	}
+
+	return toResult(result), nil
+}
+
+// costFits reports whether cost tokens fit, given first — the result of
+// having just consumed a single token. first.Remaining+1 is the capacity
+// that existed immediately before that consumption.
+func costFits(first *go_limiter.Result, cost int64) bool {
+	return first.Remaining+1 >= cost
+}
+
+func toResult(r *go_limiter.Result) *Result {
+	return &Result{
+		Allowed:    r.Allowed,
+		Remaining:  r.Remaining,
+		RetryAfter: r.RetryAfter,
+		ResetAfter: r.ResetAfter,
+	}
+}
@@ -0,0 +1,226 @@
+package http_limiter
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// memoryShardCount controls how many independent shards MemoryStore
+// spreads its buckets across, to keep lock contention low under
+// concurrent use.
+const memoryShardCount = 32
+
+// memoryBucket holds the state for a single key. GCRA fields and sliding
+// window fields are mutually exclusive in practice (a key is always
+// checked against the same Algorithm) but kept on one struct to avoid a
+// second map lookup.
+type memoryBucket struct {
+	mu sync.Mutex
+
+	// GCRA state
+	tat time.Time
+
+	// Sliding window state
+	windowStart time.Time
+	count       int64
+	prevCount   int64
+
+	expiresAt time.Time
+}
+
+type memoryShard struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// MemoryStore is an in-process Store implementation, useful for
+// single-node deployments, unit tests, and CI where a Redis dependency
+// is undesirable. Buckets are kept in sharded maps and evicted by a
+// once-a-minute sweep after sitting idle for twice their Period, similar
+// to the ttlmap approach used by Traefik's rate limiter.
+type MemoryStore struct {
+	shards [memoryShardCount]*memoryShard
+	done   chan struct{}
+}
+
+// NewMemoryStore builds a MemoryStore and starts its background
+// eviction sweep. Call Close when the store is no longer needed to stop
+// the sweep goroutine.
+func NewMemoryStore() *MemoryStore {
+	s := &MemoryStore{done: make(chan struct{})}
+
+	for i := range s.shards {
+		s.shards[i] = &memoryShard{buckets: make(map[string]*memoryBucket)}
+	}
+
+	go s.reapLoop()
+
+	return s
+}
+
+// Close stops the background eviction sweep.
+func (s *MemoryStore) Close() {
+	close(s.done)
+}
+
+func (s *MemoryStore) reapLoop() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.reap(now)
+		}
+	}
+}
+
+func (s *MemoryStore) reap(now time.Time) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for key, bucket := range shard.buckets {
+			bucket.mu.Lock()
+			expired := now.After(bucket.expiresAt)
+			bucket.mu.Unlock()
+
+			if expired {
+				delete(shard.buckets, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+func (s *MemoryStore) shardFor(key string) *memoryShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return s.shards[h.Sum32()%memoryShardCount]
+}
+
+func (s *MemoryStore) bucketFor(key string) *memoryBucket {
+	shard := s.shardFor(key)
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	bucket, ok := shard.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{}
+		shard.buckets[key] = bucket
+	}
+
+	return bucket
+}
+
+func (s *MemoryStore) Allow(key string, limit Limit, cost int64) (*Result, error) {
+	if cost <= 0 {
+		cost = 1
+	}
+
+	bucket := s.bucketFor(key)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := time.Now()
+	bucket.expiresAt = now.Add(limit.Period * 2)
+
+	if limit.Algorithm == GCRAAlgorithm {
+		return allowGCRA(bucket, limit, now, cost), nil
+	}
+
+	return allowSlidingWindow(bucket, limit, now, cost), nil
+}
+
+// allowGCRA implements the generic cell rate algorithm: each allowed
+// request advances a theoretical arrival time (tat) by cost emission
+// intervals, and Burst controls how far ahead of "now" that tat is
+// allowed to run.
+func allowGCRA(b *memoryBucket, limit Limit, now time.Time, cost int64) *Result {
+	emissionInterval := limit.Period / time.Duration(limit.Max)
+	delayVariationTolerance := emissionInterval * time.Duration(limit.Burst)
+
+	tat := b.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTat := tat.Add(emissionInterval * time.Duration(cost))
+	allowAt := newTat.Add(-delayVariationTolerance)
+
+	if now.Before(allowAt) {
+		return &Result{
+			Allowed:    false,
+			Remaining:  0,
+			RetryAfter: allowAt.Sub(now),
+			ResetAfter: tat.Sub(now),
+		}
+	}
+
+	b.tat = newTat
+
+	remaining := int64((delayVariationTolerance - newTat.Sub(now)) / emissionInterval)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &Result{
+		Allowed:    true,
+		Remaining:  remaining,
+		ResetAfter: newTat.Sub(now),
+	}
+}
+
+// allowSlidingWindow implements the sliding window counter
+// approximation: the current window's count is weighted against the
+// previous window's count by how much of the previous window overlaps
+// the sliding view. cost tokens are deducted atomically.
+func allowSlidingWindow(b *memoryBucket, limit Limit, now time.Time, cost int64) *Result {
+	if b.windowStart.IsZero() {
+		b.windowStart = now
+	}
+
+	elapsed := now.Sub(b.windowStart)
+	if elapsed >= limit.Period {
+		if elapsed < 2*limit.Period {
+			b.prevCount = b.count
+		} else {
+			b.prevCount = 0
+		}
+
+		shifts := elapsed / limit.Period
+		b.windowStart = b.windowStart.Add(shifts * limit.Period)
+		b.count = 0
+		elapsed = now.Sub(b.windowStart)
+	}
+
+	weight := float64(limit.Period-elapsed) / float64(limit.Period)
+	estimated := float64(b.prevCount)*weight + float64(b.count)
+	resetAfter := limit.Period - elapsed
+
+	if estimated+float64(cost) > float64(limit.Max) {
+		return &Result{
+			Allowed:    false,
+			Remaining:  0,
+			RetryAfter: resetAfter,
+			ResetAfter: resetAfter,
+		}
+	}
+
+	b.count += cost
+
+	remaining := int64(limit.Max) - int64(estimated+float64(cost))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &Result{
+		Allowed:    true,
+		Remaining:  remaining,
+		ResetAfter: resetAfter,
+	}
+}
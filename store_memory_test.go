@@ -0,0 +1,56 @@
+package http_limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreCostWeightedRequest(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	limit := Limit{
+		Max:       10,
+		Burst:     10,
+		Period:    time.Minute,
+		Algorithm: SlidingWindowAlgorithm,
+	}
+
+	result, err := store.Allow("client", limit, 5)
+	if err != nil {
+		t.Fatalf("Allow returned error: %v", err)
+	}
+
+	if !result.Allowed {
+		t.Fatal("expected cost-5 request to be allowed against a burst-of-10 bucket")
+	}
+
+	if result.Remaining != 5 {
+		t.Errorf("Remaining = %d, want 5", result.Remaining)
+	}
+}
+
+func TestMemoryStoreCostExceedsRemaining(t *testing.T) {
+	store := NewMemoryStore()
+	defer store.Close()
+
+	limit := Limit{
+		Max:       10,
+		Burst:     10,
+		Period:    time.Minute,
+		Algorithm: SlidingWindowAlgorithm,
+	}
+
+	if _, err := store.Allow("client", limit, 8); err != nil {
+		t.Fatalf("Allow returned error: %v", err)
+	}
+
+	result, err := store.Allow("client", limit, 5)
+	if err != nil {
+		t.Fatalf("Allow returned error: %v", err)
+	}
+
+	if result.Allowed {
+		t.Fatal("expected a cost-5 request to be denied with only 2 tokens left")
+	}
+}
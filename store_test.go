@@ -0,0 +1,45 @@
+package http_limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shareed2k/go_limiter"
+)
+
+func TestCostFits(t *testing.T) {
+	cases := []struct {
+		name      string
+		remaining int64
+		cost      int64
+		want      bool
+	}{
+		{"exact fit", 4, 5, true},
+		{"room to spare", 9, 5, true},
+		{"one short", 3, 5, false},
+		{"cost of one always fits after an allowed call", 0, 1, true},
+	}
+
+	for _, c := range cases {
+		first := &go_limiter.Result{Allowed: true, Remaining: c.remaining}
+
+		if got := costFits(first, c.cost); got != c.want {
+			t.Errorf("%s: costFits(remaining=%d, cost=%d) = %v, want %v", c.name, c.remaining, c.cost, got, c.want)
+		}
+	}
+}
+
+func TestToResult(t *testing.T) {
+	in := &go_limiter.Result{
+		Allowed:    true,
+		Remaining:  7,
+		RetryAfter: 2 * time.Second,
+		ResetAfter: 30 * time.Second,
+	}
+
+	out := toResult(in)
+
+	if out.Allowed != in.Allowed || out.Remaining != in.Remaining || out.RetryAfter != in.RetryAfter || out.ResetAfter != in.ResetAfter {
+		t.Errorf("toResult(%+v) = %+v, fields did not round-trip", in, out)
+	}
+}